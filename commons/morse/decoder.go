@@ -27,12 +27,16 @@ func clip(x, min, max int) int {
  モールス信号の文字列です。
 */
 type Message struct {
-	Data []float64
-	Code string
-	Freq int
-	Life int
-	Miss int
-	side bool
+	Data  []float64
+	Code  string
+	Lang  Lang
+	Freq  int
+	Life  int
+	Miss  int
+	Dot   float64
+	Sigma float64
+	Conf  []float64
+	side  bool
 }
 
 /*
@@ -48,6 +52,41 @@ type Decoder struct {
 	STFT *stft.STFT
 }
 
+/*
+ モールス信号の検波器が実装するインタフェースです。
+ GMMによる`Decoder`と包絡線による`EnvelopeDecoder`を切り替えて`Monitor`に接続できます。
+*/
+type Detector interface {
+	Read(signal []float64) []Message
+	Detect(signal []float64) Message
+	FrameShift() int
+	SetBand(band int)
+	Clone() Detector
+}
+
+/*
+ 単一の周波数帯に絞り込んだ信号からモールス信号の文字列を抽出します。
+*/
+func (d *Decoder) Detect(signal []float64) Message {
+	return d.detect(signal)
+}
+
+func (d *Decoder) FrameShift() int {
+	return d.STFT.FrameShift
+}
+
+func (d *Decoder) SetBand(band int) {
+	d.Band = band
+}
+
+/*
+ 設定を引き継いだまま複製を返します。
+*/
+func (d *Decoder) Clone() Detector {
+	v := *d
+	return &v
+}
+
 func (d *Decoder) binary(signal []float64) (result []*step) {
 	key := make([]float64, len(signal))
 	max := Max64(signal)
@@ -69,9 +108,11 @@ func (d *Decoder) detect(signal []float64) (result Message) {
 	copy(result.Data, signal)
 	steps := d.binary(signal)
 	tones := make([]float64, 0)
+	spans := make([]float64, 0)
 	if len(steps) >= 1 {
 		for idx, s := range steps[1:] {
 			s.span = float64(s.time - steps[idx].time)
+			spans = append(spans, s.span)
 			if s.down {
 				tones = append(tones, s.span)
 			}
@@ -80,12 +121,21 @@ func (d *Decoder) detect(signal []float64) (result Message) {
 	if len(tones) >= 1 {
 		gmm := &means{X: tones}
 		gmm.optimize(d.Iter)
-		if Min64(gmm.m) > MIN_RELIABLE_DOT {
+		fit := fitDotLength(spans, Min64(gmm.m), d.Iter)
+		result.Dot = fit.dot
+		result.Sigma = fit.sigma
+		if fit.dot > MIN_RELIABLE_DOT {
 			for _, s := range steps[1:] {
+				conf := fit.confidence(s.span)
+				var frag string
 				if s.down {
-					result.Code += s.tone(gmm.class(s.span))
+					frag = s.tone(gmm.class(s.span))
 				} else {
-					result.Code += s.mute(gmm.extra(s.span))
+					frag = s.mute(gmm.extra(s.span))
+				}
+				result.Code += frag
+				for range frag {
+					result.Conf = append(result.Conf, conf)
 				}
 			}
 		}
@@ -142,12 +192,13 @@ func (d *Decoder) Read(signal []float64) (result []Message) {
  モールス信号の逐次的な解析器です。
 */
 type Monitor struct {
-	MaxHold int
-	MaxMiss int
-	MaxBand int
-	Decoder Decoder
-	samples []float64
-	targets []Message
+	MaxHold  int
+	MaxMiss  int
+	MaxBand  int
+	Decoder  Decoder
+	Detector Detector
+	samples  []float64
+	targets  []Message
 }
 
 /*
@@ -170,22 +221,35 @@ func DefaultMonitor(SamplingRateInHz int) (monitor Monitor) {
 	}
 }
 
+/*
+ 現在有効な検波器を返します。
+ `Detector`が指定された場合はGMMの代わりに利用されます。
+*/
+func (m *Monitor) detector() Detector {
+	if m.Detector != nil {
+		return m.Detector
+	}
+	return &m.Decoder
+}
+
 func (m *Monitor) next(signal []float64) (result []Message) {
-	shift := m.Decoder.STFT.FrameShift
-	extra := m.Decoder
-	extra.Band = m.MaxBand
+	base := m.detector()
+	shift := base.FrameShift()
+	extra := base.Clone()
+	extra.SetBand(m.MaxBand)
 	for _, next := range extra.Read(m.samples) {
 		for _, prev := range m.targets {
 			if next.Freq == prev.Freq {
 				drop := len(next.Data) - (len(signal) / shift)
 				data := append(prev.Data, next.Data[drop:]...)
-				next = m.Decoder.detect(data)
+				next = base.Detect(data)
 				next.Freq = prev.Freq
 				next.Life = prev.Life
 			}
 		}
 		if !next.side {
 			next.Life += 1
+			_, next.Lang = CodeToTextAuto(next.Code)
 			result = append(result, next)
 		}
 	}
@@ -212,7 +276,7 @@ func (m *Monitor) prev(latest []Message) (result []Message) {
  音声からモールス信号の文字列を抽出します。
 */
 func (m *Monitor) Read(signal []float64) (result []Message) {
-	shift := m.Decoder.STFT.FrameShift
+	shift := m.detector().FrameShift()
 	m.samples = append(m.samples, signal...)
 	if len(m.samples) > m.MaxHold {
 		m.samples = m.samples[len(signal)/shift*shift:]
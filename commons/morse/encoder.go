@@ -0,0 +1,167 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package morse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/oto"
+)
+
+// dotUnit is the PARIS-standard dot length, in seconds, at 1 WPM.
+const dotUnit = 1.2
+
+/*
+ モールス信号の合成器です。`Decoder`とは逆に文字列を音声信号に変換します。
+*/
+type Encoder struct {
+	WPM        float64
+	Farnsworth float64
+	Freq       float64
+	SampleRate int
+	Rise       float64
+	Fall       float64
+}
+
+/*
+ 規定の設定が適用された合成器を構築します。
+*/
+func DefaultEncoder(SamplingRateInHz int) Encoder {
+	return Encoder{
+		WPM:        20,
+		Farnsworth: 20,
+		Freq:       700,
+		SampleRate: SamplingRateInHz,
+		Rise:       5,
+		Fall:       5,
+	}
+}
+
+func (e *Encoder) dot() float64 {
+	return dotUnit / e.WPM
+}
+
+func (e *Encoder) space() float64 {
+	return dotUnit / e.Farnsworth
+}
+
+func (e *Encoder) tone(seconds float64) (result []float64) {
+	n := int(seconds * float64(e.SampleRate))
+	rise := int(e.Rise / 1000 * float64(e.SampleRate))
+	fall := int(e.Fall / 1000 * float64(e.SampleRate))
+	result = make([]float64, n)
+	for i := range result {
+		env := 1.0
+		if i < rise {
+			env = 0.5 * (1 - math.Cos(math.Pi*float64(i)/float64(rise)))
+		} else if d := n - i; d < fall {
+			env = 0.5 * (1 - math.Cos(math.Pi*float64(d)/float64(fall)))
+		}
+		result[i] = env * math.Sin(2*math.Pi*e.Freq*float64(i)/float64(e.SampleRate))
+	}
+	return
+}
+
+func (e *Encoder) mute(seconds float64) []float64 {
+	return make([]float64, int(seconds*float64(e.SampleRate)))
+}
+
+/*
+ モールス信号の文字列を音声信号に変換します。
+ 単一の空白は文字間隔，連続する空白は語間隔として扱われます。
+*/
+func (e *Encoder) EncodeCode(code string) (result []float64) {
+	dot := e.dot()
+	first, wordGap := true, false
+	for _, tok := range strings.Split(code, " ") {
+		if tok == "" {
+			wordGap = true
+			continue
+		}
+		if !first {
+			if wordGap {
+				result = append(result, e.mute(7*e.space())...)
+			} else {
+				result = append(result, e.mute(3*e.space())...)
+			}
+		}
+		for i, sym := range tok {
+			if i > 0 {
+				result = append(result, e.mute(dot)...)
+			}
+			if sym == '.' {
+				result = append(result, e.tone(dot)...)
+			} else {
+				result = append(result, e.tone(3*dot)...)
+			}
+		}
+		first, wordGap = false, false
+	}
+	return
+}
+
+/*
+ 文字列を音声信号に変換します。
+*/
+func (e *Encoder) EncodeText(text string) []float64 {
+	return e.EncodeCode(TextToCode(text))
+}
+
+func (e *Encoder) pcm16(text string) []byte {
+	samples := e.EncodeText(text)
+	result := make([]byte, 2*len(samples))
+	for i, v := range samples {
+		s := int16(math.Max(-1, math.Min(1, v)) * math.MaxInt16)
+		binary.LittleEndian.PutUint16(result[2*i:], uint16(s))
+	}
+	return result
+}
+
+/*
+ 文字列を16ビットPCMのRIFF/WAVとして書き出します。
+*/
+func (e *Encoder) WriteWAV(w io.Writer, text string) (err error) {
+	pcm := e.pcm16(text)
+	head := new(bytes.Buffer)
+	head.WriteString("RIFF")
+	binary.Write(head, binary.LittleEndian, uint32(36+len(pcm)))
+	head.WriteString("WAVE")
+	head.WriteString("fmt ")
+	binary.Write(head, binary.LittleEndian, uint32(16))
+	binary.Write(head, binary.LittleEndian, uint16(1))
+	binary.Write(head, binary.LittleEndian, uint16(1))
+	binary.Write(head, binary.LittleEndian, uint32(e.SampleRate))
+	binary.Write(head, binary.LittleEndian, uint32(e.SampleRate*2))
+	binary.Write(head, binary.LittleEndian, uint16(2))
+	binary.Write(head, binary.LittleEndian, uint16(16))
+	head.WriteString("data")
+	binary.Write(head, binary.LittleEndian, uint32(len(pcm)))
+	if _, err = w.Write(head.Bytes()); err != nil {
+		return
+	}
+	_, err = w.Write(pcm)
+	return
+}
+
+/*
+ 文字列を音声として既定の出力デバイスで再生します。
+*/
+func (e *Encoder) Play(text string) (err error) {
+	pcm := e.pcm16(text)
+	ctx, err := oto.NewContext(e.SampleRate, 1, 2, len(pcm))
+	if err != nil {
+		return
+	}
+	defer ctx.Close()
+	player := ctx.NewPlayer()
+	defer player.Close()
+	_, err = player.Write(pcm)
+	return
+}
@@ -0,0 +1,221 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package morse
+
+import (
+	"github.com/r9y9/gossp"
+	"github.com/r9y9/gossp/stft"
+	"math"
+)
+
+/*
+ 包絡線の微分に基づく時間領域のモールス信号の解析器です。
+ STFTとGMMによる`Decoder`とは異なり，鍵の開閉をエンベロープの傾きから直接検出します。
+*/
+type EnvelopeDecoder struct {
+	Bias int
+	Band int
+	Loud float64
+	Taps int
+	Pass int
+	Edge float64
+	STFT *stft.STFT
+}
+
+/*
+ 規定の設定が適用された包絡線検波器を構築します。
+*/
+func DefaultEnvelopeDecoder(SamplingRateInHz int) EnvelopeDecoder {
+	return EnvelopeDecoder{
+		Bias: 5,
+		Band: 0,
+		Loud: 0.01,
+		Taps: 8,
+		Pass: 1,
+		Edge: 0.7,
+		STFT: stft.New(SamplingRateInHz/100, 2048),
+	}
+}
+
+// beat estimates the dominant keying period, in samples, so the moving
+// average LPF cutoff can track the keying rate and not the CW tone itself.
+func beat(signal []float64) (period int) {
+	best := 0.0
+	for p := 2; p < len(signal)/2; p++ {
+		re, im, w := 0.0, 0.0, 2*math.Pi/float64(p)
+		for i, v := range signal {
+			re += v * math.Cos(w*float64(i))
+			im += v * math.Sin(w*float64(i))
+		}
+		if pow := re*re + im*im; pow > best {
+			best = pow
+			period = p
+		}
+	}
+	return
+}
+
+func lpf(signal []float64, taps int) (result []float64) {
+	result = make([]float64, len(signal))
+	for i := range signal {
+		sum, n := 0.0, 0
+		for k := -taps / 2; k <= taps/2; k++ {
+			if j := i + k; j >= 0 && j < len(signal) {
+				sum += signal[j]
+				n++
+			}
+		}
+		result[i] = sum / float64(n)
+	}
+	return
+}
+
+type edge struct {
+	time int
+	up   bool
+}
+
+// edges smooths the instantaneous power into an envelope, differentiates it,
+// and marks the rising/falling edges where the derivative crosses a fraction
+// (d.Edge) of its positive/negative peak.
+//
+// The moving-average window is kept to a small fraction of the keying period
+// (beat/d.Taps), not the period itself: a kernel close to a full dot width
+// blurs adjacent dot/gap segments together before diff ever sees them, so
+// only the keying noise is smoothed away, not the keying itself.
+func (d *EnvelopeDecoder) edges(signal []float64) (result []edge) {
+	taps := clip(beat(signal)/d.Taps, 3, len(signal)/4+1)
+	env := make([]float64, len(signal))
+	for i, v := range signal {
+		env[i] = v * v
+	}
+	for i := 0; i < d.Pass; i++ {
+		env = lpf(env, taps)
+	}
+	diff := make([]float64, len(env))
+	for i := 1; i < len(env); i++ {
+		diff[i] = env[i] - env[i-1]
+	}
+	hi, lo := Max64(diff), Min64(diff)
+	up := false
+	for i, v := range diff {
+		if !up && v > hi*d.Edge {
+			result = append(result, edge{i, true})
+			up = true
+		} else if up && v < lo*d.Edge {
+			result = append(result, edge{i, false})
+			up = false
+		}
+	}
+	return
+}
+
+// detect turns the isolated envelope of a single frequency bin into a code
+// string, using the shortest inter-edge interval as the dot length T.
+//
+// The shortest-interval estimate is not always safe (e.g. a transmission
+// made only of dashes and word gaps); a proper MLE fit over the {1,3,7}*T
+// clusters is left as a future improvement, as in the cwListener reference.
+func (d *EnvelopeDecoder) detect(signal []float64) (result Message) {
+	result.Data = make([]float64, len(signal))
+	copy(result.Data, signal)
+	steps := d.edges(signal)
+	if len(steps) < 2 {
+		return
+	}
+	dot := math.MaxFloat64
+	for i := 1; i < len(steps); i++ {
+		if span := float64(steps[i].time - steps[i-1].time); span < dot {
+			dot = span
+		}
+	}
+	if dot <= MIN_RELIABLE_DOT {
+		return
+	}
+	for i := 1; i < len(steps); i++ {
+		span := float64(steps[i].time-steps[i-1].time) / dot
+		if steps[i-1].up {
+			if span < 2 {
+				result.Code += "."
+			} else {
+				result.Code += "-"
+			}
+		} else if span >= 2 && span < 5 {
+			result.Code += " "
+		} else if span >= 5 {
+			result.Code += "  "
+		}
+	}
+	return
+}
+
+func (d *EnvelopeDecoder) search(spectrum []float64) (result []int) {
+	lev := d.Loud * Sum64(spectrum)
+	top := 0.0
+	pos := 0
+	for idx, val := range spectrum {
+		if val > top {
+			top = val
+			pos = idx
+		} else if val < lev && top > lev {
+			result = append(result, d.Bias+pos)
+			top = 0
+			pos = 0
+		}
+	}
+	return
+}
+
+/*
+ 音声からモールス信号の文字列を抽出します。
+ GMMではなく包絡線の傾きの解析に基づき，複数の周波数のモールス信号を分離できます。
+*/
+func (d *EnvelopeDecoder) Read(signal []float64) (result []Message) {
+	spec, _ := gossp.SplitSpectrogram(d.STFT.STFT(signal))
+	dist := make([]float64, d.STFT.FrameLen/2)
+	for _, s := range spec {
+		for idx, val := range s[d.Bias:len(dist)] {
+			dist[idx] += val * val
+		}
+	}
+	buff := make([]float64, len(spec))
+	for _, idx := range d.search(dist) {
+		for n := -d.Band; n <= d.Band; n++ {
+			for t, s := range spec {
+				buff[t] = s[clip(idx+n, 0, len(dist)-1)]
+			}
+			if m := d.detect(buff); m.Code != "" {
+				m.side = n != 0
+				m.Freq = int(idx + n)
+				result = append(result, m)
+			}
+		}
+	}
+	return
+}
+
+/*
+ 単一の周波数帯に絞り込んだ信号からモールス信号の文字列を抽出します。
+*/
+func (d *EnvelopeDecoder) Detect(signal []float64) Message {
+	return d.detect(signal)
+}
+
+func (d *EnvelopeDecoder) FrameShift() int {
+	return d.STFT.FrameShift
+}
+
+func (d *EnvelopeDecoder) SetBand(band int) {
+	d.Band = band
+}
+
+/*
+ 設定を引き継いだまま複製を返します。
+*/
+func (d *EnvelopeDecoder) Clone() Detector {
+	v := *d
+	return &v
+}
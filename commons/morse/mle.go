@@ -0,0 +1,170 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package morse
+
+import "math"
+
+// ratios are the {1,3,7}*T clusters a dot/dash/gap length can belong to:
+// the dot or intra-character gap, the dash or character gap, the word gap.
+var ratios = [3]float64{1, 3, 7}
+
+/*
+ モールス信号の間隔から単位時間Tを推定するEMアルゴリズムの結果です。
+*/
+type dotFit struct {
+	dot    float64
+	sigma  float64
+	weight [3]float64
+}
+
+// seedRatios are the multiples of the k-means seed tried as EM starting
+// points: the k-means estimate may itself be a dash, a character gap, or a
+// word gap rather than a bare dot, so the seed is rescaled by the inverse of
+// each {1,3,7} ratio (and the ratios themselves, in case it undershoots) to
+// give the EM a chance to fall into the correct basin of attraction.
+var seedRatios = [5]float64{1, 1.0 / 3, 1.0 / 7, 3, 7}
+
+// fitDotLength fits a mixture of three log-normal components, whose means
+// are pinned to the {1,3,7}*T ratio of dot/dash/gap lengths, to the observed
+// on/off interval spans. It replaces the naive shortest-interval estimate,
+// which is unsafe when a transmission never contains a bare dot (or a bare
+// dash), by an EM fit seeded from the k-means result already computed for
+// the on-segment spans.
+//
+// A single EM run is liable to converge on a stable-but-wrong fixed point
+// when `init` is not already close to the true dot length (e.g. the
+// shortest on-segment span is itself a dash or a gap), so the fit is
+// restarted from several rescaled seeds and the run with the highest
+// log-likelihood under its own fit wins.
+func fitDotLength(spans []float64, init float64, iter int) (result dotFit) {
+	if init <= 0 || len(spans) == 0 {
+		result.dot = init
+		result.sigma = 0.3
+		result.weight = [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+		return
+	}
+	best := math.Inf(-1)
+	for _, ratio := range seedRatios {
+		fit := emFit(spans, init*ratio, iter)
+		if ll := logLikelihood(spans, fit); ll > best {
+			best, result = ll, fit
+		}
+	}
+	return
+}
+
+// logLikelihood scores a dotFit against the observed spans, so restarts
+// from different seeds can be compared and the most plausible one kept.
+func logLikelihood(spans []float64, fit dotFit) float64 {
+	if fit.dot <= 0 {
+		return math.Inf(-1)
+	}
+	logT := math.Log(fit.dot)
+	total := 0.0
+	for _, s := range spans {
+		y := math.Log(math.Max(s, 1e-9))
+		lik := 0.0
+		for k, r := range ratios {
+			lik += fit.weight[k] * gauss(y, logT+math.Log(r), fit.sigma)
+		}
+		total += math.Log(math.Max(lik, 1e-300))
+	}
+	return total
+}
+
+// emFit runs a single EM fit seeded at `init`, without trying alternate
+// seeds; see fitDotLength for the restart strategy built on top of it.
+func emFit(spans []float64, init float64, iter int) (result dotFit) {
+	result.dot = init
+	result.sigma = 0.3
+	result.weight = [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	if result.dot <= 0 || len(spans) == 0 {
+		return
+	}
+	logs := make([]float64, len(spans))
+	for i, s := range spans {
+		logs[i] = math.Log(math.Max(s, 1e-9))
+	}
+	resp := make([][3]float64, len(spans))
+	for n := 0; n < iter; n++ {
+		logT := math.Log(result.dot)
+		for i, y := range logs {
+			var lik [3]float64
+			sum := 0.0
+			for k, r := range ratios {
+				mu := logT + math.Log(r)
+				lik[k] = result.weight[k] * gauss(y, mu, result.sigma)
+				sum += lik[k]
+			}
+			if sum <= 0 {
+				resp[i] = [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+				continue
+			}
+			for k := range lik {
+				resp[i][k] = lik[k] / sum
+			}
+		}
+		var numT, denT, variance, total float64
+		var weight [3]float64
+		for i, y := range logs {
+			for k, r := range ratios {
+				w := resp[i][k]
+				numT += w * (y - math.Log(r))
+				denT += w
+				weight[k] += w
+			}
+		}
+		if denT > 0 {
+			logT = numT / denT
+		}
+		newDot := math.Exp(logT)
+		for i, y := range logs {
+			for k, r := range ratios {
+				mu := logT + math.Log(r)
+				variance += resp[i][k] * (y - mu) * (y - mu)
+				total += resp[i][k]
+			}
+		}
+		result.dot = newDot
+		if total > 0 {
+			result.sigma = math.Max(math.Sqrt(variance/total), 1e-3)
+		}
+		for k := range weight {
+			result.weight[k] = weight[k] / float64(len(spans))
+		}
+	}
+	return
+}
+
+// confidence reports the posterior probability of the winning {1,3,7}*T
+// cluster for a single observed span, given the fitted T and sigma.
+func (f dotFit) confidence(span float64) float64 {
+	if f.dot <= 0 {
+		return 0
+	}
+	y, logT := math.Log(math.Max(span, 1e-9)), math.Log(f.dot)
+	var lik [3]float64
+	sum, best := 0.0, 0.0
+	for k, r := range ratios {
+		mu := logT + math.Log(r)
+		lik[k] = f.weight[k] * gauss(y, mu, f.sigma)
+		sum += lik[k]
+	}
+	if sum <= 0 {
+		return 0
+	}
+	for _, l := range lik {
+		if p := l / sum; p > best {
+			best = p
+		}
+	}
+	return best
+}
+
+func gauss(x, mu, sigma float64) float64 {
+	z := (x - mu) / sigma
+	return math.Exp(-0.5*z*z) / (sigma * math.Sqrt(2*math.Pi))
+}
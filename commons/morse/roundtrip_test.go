@@ -0,0 +1,54 @@
+package morse
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	const rate = 8000
+	enc := DefaultEncoder(rate)
+	text := "CQ"
+	samples := enc.EncodeText(text)
+
+	monitor := DefaultMonitor(rate)
+	shift := monitor.Decoder.STFT.FrameShift
+	var messages []Message
+	for pos := 0; pos+shift <= len(samples); pos += shift {
+		messages = append(messages, monitor.Read(samples[pos:pos+shift])...)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected at least one decoded message")
+	}
+	if got := CodeToText(messages[len(messages)-1].Code); got != text {
+		t.Errorf("CodeToText(...) = %q, want %q", got, text)
+	}
+}
+
+func TestEnvelopeDecoderRoundTrip(t *testing.T) {
+	const rate = 8000
+	enc := DefaultEncoder(rate)
+	text := "SOS"
+	samples := enc.EncodeText(text)
+
+	dec := DefaultEnvelopeDecoder(rate)
+	messages := dec.Read(samples)
+	if len(messages) == 0 {
+		t.Fatal("expected at least one decoded message")
+	}
+	if got := CodeToText(messages[0].Code); got != text {
+		t.Errorf("CodeToText(...) = %q, want %q", got, text)
+	}
+}
+
+func TestFitDotLengthSanity(t *testing.T) {
+	const trueDot = 12.0
+	var spans []float64
+	for i := 0; i < 20; i++ {
+		spans = append(spans, trueDot, 3*trueDot, 7*trueDot)
+	}
+	fit := fitDotLength(spans, trueDot*0.5, 10)
+	if math.Abs(fit.dot-trueDot) > trueDot*0.2 {
+		t.Errorf("fitDotLength dot = %v, want close to %v", fit.dot, trueDot)
+	}
+}
@@ -0,0 +1,82 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package sdr
+
+import "math"
+
+// weaver demodulates a complex IQ block into a real, single-sideband
+// audio-band signal tuned at `tone`, by the classic phasing method: split
+// the downmix into its in-phase and quadrature components, then cancel the
+// image sideband by subtracting a Hilbert-shifted quadrature component from
+// the in-phase one. Unlike a bare `real(s*lo)` downmix, this does not fold
+// content above and below `tone` onto the same output frequency, so distinct
+// CW stations on either side of `tone` remain distinguishable.
+func weaver(iq []complex128, sampleRate, tone float64) []float64 {
+	inPhase := make([]float64, len(iq))
+	quad := make([]float64, len(iq))
+	w := 2 * math.Pi * tone / sampleRate
+	for i, s := range iq {
+		c, sN := math.Cos(w*float64(i)), math.Sin(w*float64(i))
+		inPhase[i] = real(s)*c + imag(s)*sN
+		quad[i] = imag(s)*c - real(s)*sN
+	}
+	shifted := hilbert(quad)
+	result := make([]float64, len(iq))
+	for i := range result {
+		result[i] = inPhase[i] - shifted[i]
+	}
+	return result
+}
+
+// decimate low-pass filters and downsamples a demodulated block by the
+// given integer factor, so a wideband RF capture can be reduced to the
+// audio rate `Monitor`'s STFT bin width actually expects. A plain boxcar
+// average over each output period both suppresses energy above the new
+// Nyquist rate and collapses it to one sample, so no separate LPF pass is
+// needed ahead of it.
+func decimate(signal []float64, factor int) []float64 {
+	if factor <= 1 {
+		return signal
+	}
+	result := make([]float64, len(signal)/factor)
+	for i := range result {
+		sum := 0.0
+		for _, v := range signal[i*factor : (i+1)*factor] {
+			sum += v
+		}
+		result[i] = sum / float64(factor)
+	}
+	return result
+}
+
+// hilbert approximates the Hilbert transform (a 90 degree phase shift at
+// every frequency) with a truncated, Hamming-windowed FIR. It is the phase
+// shift the phasing-method SSB demodulator above needs to cancel the
+// image sideband before collapsing the signal to a single real channel.
+func hilbert(signal []float64) []float64 {
+	const taps = 31
+	mid := taps / 2
+	h := make([]float64, taps)
+	for i := range h {
+		n := i - mid
+		if n%2 == 0 {
+			continue
+		}
+		win := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(taps-1))
+		h[i] = win * 2 / (math.Pi * float64(n))
+	}
+	result := make([]float64, len(signal))
+	for i := range signal {
+		sum := 0.0
+		for k, c := range h {
+			if j := i - (k - mid); j >= 0 && j < len(signal) {
+				sum += c * signal[j]
+			}
+		}
+		result[i] = sum
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+//go:build rtlsdr
+
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package sdr
+
+/*
+#cgo pkg-config: librtlsdr
+#include <rtl-sdr.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+/*
+ rtl-sdrをIQ信号源として扱う`Source`の実装です。
+*/
+type RTLSDR struct {
+	dev *C.rtlsdr_dev_t
+}
+
+/*
+ 指定の番号のrtl-sdrデバイスを開きます。
+*/
+func OpenRTLSDR(index int) (result *RTLSDR, err error) {
+	var dev *C.rtlsdr_dev_t
+	if rc := C.rtlsdr_open(&dev, C.uint32_t(index)); rc != 0 {
+		return nil, errors.New("rtlsdr_open failed")
+	}
+	return &RTLSDR{dev: dev}, nil
+}
+
+func (r *RTLSDR) SetCenterFreq(hz float64) error {
+	if rc := C.rtlsdr_set_center_freq(r.dev, C.uint32_t(hz)); rc != 0 {
+		return errors.New("rtlsdr_set_center_freq failed")
+	}
+	return nil
+}
+
+func (r *RTLSDR) SetSampleRate(hz float64) error {
+	if rc := C.rtlsdr_set_sample_rate(r.dev, C.uint32_t(hz)); rc != 0 {
+		return errors.New("rtlsdr_set_sample_rate failed")
+	}
+	return nil
+}
+
+func (r *RTLSDR) SetBandwidth(hz float64) error {
+	if rc := C.rtlsdr_set_tuner_bandwidth(r.dev, C.uint32_t(hz)); rc != 0 {
+		return errors.New("rtlsdr_set_tuner_bandwidth failed")
+	}
+	return nil
+}
+
+func (r *RTLSDR) SetGain(mode GainMode, value float64) error {
+	if mode == GainAuto {
+		if rc := C.rtlsdr_set_tuner_gain_mode(r.dev, 0); rc != 0 {
+			return errors.New("rtlsdr_set_tuner_gain_mode failed")
+		}
+		return nil
+	}
+	if rc := C.rtlsdr_set_tuner_gain_mode(r.dev, 1); rc != 0 {
+		return errors.New("rtlsdr_set_tuner_gain_mode failed")
+	}
+	if rc := C.rtlsdr_set_tuner_gain(r.dev, C.int(value*10)); rc != 0 {
+		return errors.New("rtlsdr_set_tuner_gain failed")
+	}
+	return nil
+}
+
+// rtl-sdr delivers unsigned 8-bit interleaved IQ samples centered on 127.5.
+func (r *RTLSDR) ReadIQ(n int) (result []complex128, err error) {
+	buf := make([]byte, 2*n)
+	var read C.int
+	rc := C.rtlsdr_read_sync(r.dev, unsafe.Pointer(&buf[0]), C.int(2*n), &read)
+	if rc != 0 {
+		return nil, errors.New("rtlsdr_read_sync failed")
+	}
+	if int(read) != 2*n {
+		return nil, errors.New("rtlsdr_read_sync short read")
+	}
+	result = make([]complex128, n)
+	for i := 0; i < n; i++ {
+		result[i] = complex((float64(buf[2*i])-127.5)/127.5, (float64(buf[2*i+1])-127.5)/127.5)
+	}
+	return
+}
+
+func (r *RTLSDR) Close() error {
+	C.rtlsdr_close(r.dev)
+	return nil
+}
@@ -0,0 +1,98 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package sdr
+
+import (
+	"context"
+
+	"github.com/jg1vpp/zylo/commons/morse"
+)
+
+// audioRate is the sample rate `Monitor` analyzes at. The SDR's own
+// SampleRate is almost always far higher than this (wideband IQ capture
+// for a panadapter view), so Run decimates the demodulated audio down to
+// this rate before handing it to Monitor.
+const audioRate = 8000
+
+/*
+ SDR受信機からIQ信号を取得し，`Monitor`へ復調音声を供給するスキマーです。
+ 受信帯域内の複数のCW局を`Monitor`が既存の周波数別追跡で分離します。
+*/
+type Skimmer struct {
+	Source     Source
+	Monitor    morse.Monitor
+	CenterFreq float64
+	SampleRate float64
+	Tone       float64
+	decim      int
+}
+
+/*
+ 中心周波数・サンプルレート・帯域・利得方式を設定した`Skimmer`を構築します。
+*/
+func NewSkimmer(src Source, centerFreq, sampleRate, bandwidth float64, gain GainMode) (result *Skimmer, err error) {
+	if err = src.SetCenterFreq(centerFreq); err != nil {
+		return
+	}
+	if err = src.SetSampleRate(sampleRate); err != nil {
+		return
+	}
+	if err = src.SetBandwidth(bandwidth); err != nil {
+		return
+	}
+	if err = src.SetGain(gain, 0); err != nil {
+		return
+	}
+	decim := clip(int(sampleRate/audioRate), 1)
+	result = &Skimmer{
+		Source:     src,
+		Monitor:    morse.DefaultMonitor(audioRate),
+		CenterFreq: centerFreq,
+		SampleRate: sampleRate,
+		Tone:       700,
+		decim:      decim,
+	}
+	return
+}
+
+/*
+ 受信を開始し，復調した音声を`Monitor`へ流し込んで検出結果を返し続けます。
+ `Message.Freq`はSTFTのビン番号ではなく絶対周波数（Hz）に変換されます。
+*/
+func (s *Skimmer) Run(ctx context.Context) (<-chan morse.Message, <-chan error) {
+	out := make(chan morse.Message)
+	errs := make(chan error, 1)
+	shift := s.Monitor.Decoder.STFT.FrameShift
+	bin := audioRate / float64(s.Monitor.Decoder.STFT.FrameLen)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			iq, err := s.Source.ReadIQ(shift * s.decim)
+			if err != nil {
+				errs <- err
+				return
+			}
+			audio := decimate(weaver(iq, s.SampleRate, s.Tone), s.decim)
+			for _, m := range s.Monitor.Read(audio) {
+				m.Freq = int(s.CenterFreq + s.Tone + float64(m.Freq)*bin)
+				out <- m
+			}
+		}
+	}()
+	return out, errs
+}
+
+func clip(x, min int) int {
+	if x < min {
+		return min
+	}
+	return x
+}
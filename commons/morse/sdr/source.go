@@ -0,0 +1,33 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+
+// Package sdr feeds `morse.Monitor` from a live software-defined-radio
+// receiver instead of a pre-recorded audio file. The IQ hardware backend
+// (bladeRF, rtl-sdr, ...) is selected by build tag; this file declares the
+// interface every backend implements and is always compiled.
+package sdr
+
+/*
+ SDR受信機の利得制御方式です。
+*/
+type GainMode int
+
+const (
+	GainAuto GainMode = iota
+	GainManual
+)
+
+/*
+ IQ信号を供給するSDR受信機が実装するインタフェースです。
+*/
+type Source interface {
+	SetCenterFreq(hz float64) error
+	SetSampleRate(hz float64) error
+	SetBandwidth(hz float64) error
+	SetGain(mode GainMode, value float64) error
+	ReadIQ(n int) ([]complex128, error)
+	Close() error
+}
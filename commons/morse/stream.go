@@ -0,0 +1,142 @@
+/*******************************************************************************
+ * Amateur Radio Operational Logging Software 'ZyLO' since 2020 June 22nd
+ * Released under the MIT License (or GPL v3 until 2021 Oct 28th) (see LICENSE)
+ * Univ. Tokyo Amateur Radio Club Development Task Force (https://nextzlog.dev)
+*******************************************************************************/
+package morse
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+/*
+ インタリーブされたモノラルPCMバイト列を`Monitor`へ流し込む`io.Writer`です。
+ 16ビット整数または32ビット浮動小数点のいずれかの形式を受け付けます。
+*/
+type MonitorWriter struct {
+	Monitor *Monitor
+	Float32 bool
+	Result  []Message
+	buffer  []byte
+}
+
+/*
+ 指定の`Monitor`へ書き込む`MonitorWriter`を構築します。
+*/
+func NewMonitorWriter(monitor *Monitor) *MonitorWriter {
+	return &MonitorWriter{Monitor: monitor}
+}
+
+func (w *MonitorWriter) bytesPerSample() int {
+	if w.Float32 {
+		return 4
+	}
+	return 2
+}
+
+/*
+ PCMバイト列を`Monitor`へ流し込み，検出結果を`Result`へ蓄積します。
+ サンプル境界に満たない端数は次回の書き込みまで保持されます。
+*/
+func (w *MonitorWriter) Write(p []byte) (n int, err error) {
+	w.buffer = append(w.buffer, p...)
+	unit := w.bytesPerSample()
+	frames := len(w.buffer) / unit
+	signal := make([]float64, frames)
+	for i := 0; i < frames; i++ {
+		b := w.buffer[i*unit:]
+		if w.Float32 {
+			signal[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		} else {
+			signal[i] = float64(int16(binary.LittleEndian.Uint16(b))) / math.MaxInt16
+		}
+	}
+	w.buffer = w.buffer[frames*unit:]
+	w.Result = append(w.Result, w.Monitor.Read(signal)...)
+	return len(p), nil
+}
+
+func riffChunks(data []byte) (chunks map[string][]byte, err error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, errors.New("morse: not a RIFF/WAVE stream")
+	}
+	chunks = make(map[string][]byte)
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if pos+8+size > len(data) {
+			break
+		}
+		chunks[id] = data[pos+8 : pos+8+size]
+		pos += 8 + size + size%2
+	}
+	return
+}
+
+/*
+ RIFF/WAVを読み込んで16ビットPCMモノラル信号として解析し，検出結果を返します。
+*/
+func DecodeWAV(r io.Reader) (result []Message, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	chunks, err := riffChunks(data)
+	if err != nil {
+		return
+	}
+	format, ok := chunks["fmt "]
+	pcm, hasData := chunks["data"]
+	if !ok || !hasData || len(format) < 16 {
+		return nil, errors.New("morse: missing fmt or data chunk")
+	}
+	channels := binary.LittleEndian.Uint16(format[2:4])
+	sampleRate := binary.LittleEndian.Uint32(format[4:8])
+	bits := binary.LittleEndian.Uint16(format[14:16])
+	if channels != 1 || bits != 16 {
+		return nil, errors.New("morse: only 16-bit mono PCM is supported")
+	}
+	signal := make([]float64, len(pcm)/2)
+	for i := range signal {
+		signal[i] = float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / math.MaxInt16
+	}
+	monitor := DefaultMonitor(int(sampleRate))
+	shift := monitor.Decoder.STFT.FrameShift
+	for pos := 0; pos+shift <= len(signal); pos += shift {
+		result = append(result, monitor.Read(signal[pos:pos+shift])...)
+	}
+	return
+}
+
+/*
+ 任意の音声供給源から`Monitor`へ信号を流し込むチャネル版のヘルパーです。
+ マイク(portaudio)や`.wav`ファイル，SDR受信機など`[]float64`を送れる供給源を選びません。
+*/
+func (m *Monitor) Stream(ctx context.Context, in <-chan []float64) <-chan Message {
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case signal, ok := <-in:
+				if !ok {
+					return
+				}
+				for _, msg := range m.Read(signal) {
+					select {
+					case out <- msg:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
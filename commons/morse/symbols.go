@@ -13,19 +13,74 @@ import (
 
 //go:embed latin.dat
 var morse string
+
+//go:embed wabun.dat
+var wabun string
+
 var reverse = make(map[string]rune)
 var forward = make(map[rune]string)
+var reverseJA = make(map[string]rune)
+var forwardJA = make(map[rune]string)
 
-func init() {
-	reader := strings.NewReader(morse)
-	stream := bufio.NewScanner(reader)
+func loadTable(data string, reverse map[string]rune, forward map[rune]string) {
+	stream := bufio.NewScanner(strings.NewReader(data))
 	for stream.Scan() {
-		val := stream.Text()
-		reverse[val[1:]] = rune(val[0])
-		forward[rune(val[0])] = val[1:]
+		val := []rune(stream.Text())
+		reverse[string(val[1:])] = val[0]
+		forward[val[0]] = string(val[1:])
 	}
 }
 
+// dakuten maps precomposed voiced katakana to their base (unvoiced) form.
+// Wabun code sends these as the base kana's code immediately followed by
+// the dakuten mark's code, not as a code of their own.
+var dakuten = map[rune]rune{
+	'ガ': 'カ', 'ギ': 'キ', 'グ': 'ク', 'ゲ': 'ケ', 'ゴ': 'コ',
+	'ザ': 'サ', 'ジ': 'シ', 'ズ': 'ス', 'ゼ': 'セ', 'ゾ': 'ソ',
+	'ダ': 'タ', 'ヂ': 'チ', 'ヅ': 'ツ', 'デ': 'テ', 'ド': 'ト',
+	'バ': 'ハ', 'ビ': 'ヒ', 'ブ': 'フ', 'ベ': 'ヘ', 'ボ': 'ホ',
+	'ヴ': 'ウ',
+}
+
+// handakuten maps precomposed semi-voiced katakana to their base form, in
+// the same way dakuten does for the voiced ones.
+var handakuten = map[rune]rune{
+	'パ': 'ハ', 'ピ': 'ヒ', 'プ': 'フ', 'ペ': 'ヘ', 'ポ': 'ホ',
+}
+
+// composeJA extends forwardJA/reverseJA with the codes for precomposed
+// voiced and semi-voiced katakana, each built from its base kana's code
+// followed by the dakuten/handakuten mark's code, so callers never have to
+// decompose those runes themselves.
+func composeJA() {
+	for voiced, base := range dakuten {
+		code := forwardJA[base] + " " + forwardJA['゛']
+		forwardJA[voiced] = code
+		reverseJA[code] = voiced
+	}
+	for voiced, base := range handakuten {
+		code := forwardJA[base] + " " + forwardJA['゜']
+		forwardJA[voiced] = code
+		reverseJA[code] = voiced
+	}
+}
+
+func init() {
+	loadTable(morse, reverse, forward)
+	loadTable(wabun, reverseJA, forwardJA)
+	composeJA()
+}
+
+/*
+ モールス信号の言語です。
+*/
+type Lang string
+
+const (
+	LangEN Lang = "EN"
+	LangJA Lang = "JA"
+)
+
 /*
  モールス信号の文字列を欧文に変換します。
 */
@@ -53,3 +108,55 @@ func TextToCode(text string) (result string) {
 		return
 	}
 }
+
+/*
+ モールス信号の文字列を和文（欧文カナ）に変換します。
+*/
+func CodeToTextJA(code string) (result string) {
+	for _, s := range strings.Split(code, " ") {
+		if val, ok := reverseJA[s]; ok {
+			result += string(val)
+		} else {
+			result += "?"
+		}
+	}
+	return
+}
+
+/*
+ 和文（欧文カナ）をモールス信号の文字列に変換します。
+ 符号を持たない文字は，語間隔と誤認されないよう出力から除かれます。
+*/
+func TextToCodeJA(text string) (result string) {
+	for _, s := range text {
+		if code, ok := forwardJA[s]; ok {
+			result += " " + code
+		}
+	}
+	if result != "" {
+		return result[1:]
+	} else {
+		return
+	}
+}
+
+/*
+ モールス信号の文字列を欧文と和文のどちらで解釈すべきか判定し，変換します。
+ 各分節が実在の符号として解決する割合を言語ごとに集計し，優勢な方を採用します。
+*/
+func CodeToTextAuto(code string) (result string, lang Lang) {
+	tokens := strings.Split(code, " ")
+	scoreEN, scoreJA := 0, 0
+	for _, s := range tokens {
+		if _, ok := reverse[s]; ok {
+			scoreEN++
+		}
+		if _, ok := reverseJA[s]; ok {
+			scoreJA++
+		}
+	}
+	if scoreJA > scoreEN {
+		return CodeToTextJA(code), LangJA
+	}
+	return CodeToText(code), LangEN
+}